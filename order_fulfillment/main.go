@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/nextmv-io/sdk/mip"
@@ -13,6 +14,20 @@ import (
 	"github.com/nextmv-io/sdk/run"
 )
 
+// defaultMaxCartons bounds the number of cartons a dc-carrier combination may
+// use when the input does not set an explicit cap via MaxCartons.
+const defaultMaxCartons = 1000
+
+// maxCartonsFor returns the carton cap that applies to a dc-carrier
+// combination, falling back to defaultMaxCartons when the input does not
+// set an explicit one via MaxCartons.
+func maxCartonsFor(input input, dcID, carrier string) int {
+	if limit, ok := input.MaxCartons[dcID][carrier]; ok {
+		return limit
+	}
+	return defaultMaxCartons
+}
+
 // This is a Integer Programming model to solve the order fulfillment problem.
 // We created this model, by initializing the mip-knapsack template from the
 // nextmv CLI first and then modifying it to this specific use case.
@@ -38,6 +53,7 @@ type item struct {
 	ItemID 			string  	`json:"item_id,omitempty"`
 	Quantity		float64 	`json:"quantity"`
 	Volume			float64		`json:"volume"`
+	Weight			float64		`json:"weight,omitempty"`
 }
 
 // ID is implemented to fulfill the model.Identifier interface.
@@ -48,12 +64,66 @@ func (i item) ID() string {
 // A knapsack holds the most valuable set of items possible while not exceeding
 // its carrying capacity.
 type input struct {
-	Items          		[]item 							`json:"items"`
+	Orders          	[]order 						`json:"orders"`
 	WeightCapacity 		int    							`json:"weight_capacity"`
 	DCs					[]dc 							`json:"dcs"`
 	CarrierCapacities	map[string]map[string]float64	`json:"carrier_capacities"`
-	DeliveryCosts		map[string]map[string]float64	`json:"carrier_deliverycost"`
+	// DeliveryCosts maps dc and carrier to that lane's piecewise delivery
+	// cost curve. A curve must span from 0 cartons up to at least the
+	// lane's carton cap (MaxCartons, or defaultMaxCartons when unset): the
+	// carton variable is pinned to the curve's breakpoint range, so a curve
+	// that starts above 0 or ends short of the cap makes the model
+	// infeasible or forces phantom cartons on unused lanes.
+	DeliveryCosts		map[string]map[string][]costBreakpoint	`json:"carrier_deliverycost"`
 	CartonVolume		float64							`json:"carton_volume"`
+	// CartonWeightLimits caps the weight a single carton may carry, per dc
+	// and carrier. A dc-carrier combination without an entry here is
+	// considered weight-unconstrained.
+	CartonWeightLimits	map[string]map[string]float64	`json:"carton_weight_limits,omitempty"`
+	// MaxCartons caps how many cartons a dc-carrier combination may use. A
+	// dc-carrier combination without an entry here falls back to
+	// defaultMaxCartons.
+	MaxCartons			map[string]map[string]int		`json:"max_cartons,omitempty"`
+	// SplitPenalty is added to the objective once per dc an order is
+	// fulfilled from, discouraging splitting a single order across
+	// distribution centers. Every fulfilled order uses at least one dc, so
+	// the reported objective Value always includes one SplitPenalty per
+	// order as a baseline; only the relative cost of additional dcs affects
+	// which plan the solver picks.
+	SplitPenalty		float64							`json:"split_penalty,omitempty"`
+	// MaxSplits caps, per order ID, how many distinct dcs that order may be
+	// fulfilled from. An order without an entry here is unconstrained.
+	MaxSplits			map[string]int					`json:"max_splits,omitempty"`
+	// InitialSolution seeds the solver with a previous plan (or a greedy
+	// heuristic's output) as a MIP start, which can dramatically cut solve
+	// time when re-optimizing after small inventory updates. Assignments
+	// for combinations not present here start unseeded.
+	InitialSolution		[]assignment					`json:"initial_solution,omitempty"`
+}
+
+// An order groups the items a single customer requested, bound for a single
+// destination zone. Orders share the same pool of dc inventory and carrier
+// capacity when solved together.
+type order struct {
+	OrderID			string	`json:"order_id"`
+	Items			[]item	`json:"items"`
+	DestinationZone	string	`json:"destination_zone,omitempty"`
+}
+
+// ID is implemented to fulfill the model.Identifier interface.
+func (o order) ID() string {
+	return o.OrderID
+}
+
+// costBreakpoint is one point on a piecewise-linear delivery cost curve: the
+// cost of shipping exactly Cartons cartons. DeliveryCosts interpolates
+// linearly between consecutive breakpoints. A single breakpoint is
+// interpreted as a flat per-carton rate. When a lane has two or more
+// breakpoints, its curve must start at Cartons: 0 and reach the lane's
+// carton cap (see DeliveryCosts).
+type costBreakpoint struct {
+	Cartons	int		`json:"cartons"`
+	Cost	float64	`json:"cost"`
 }
 
 type dc struct{
@@ -76,14 +146,29 @@ func (i carrier) ID() string{
 }
 
 type assignment struct{
+	Order	string	`json:"order_id"`
 	Item	item	`json:"item"`
 	DC		dc		`json:"dc"`
 	Carrier	string	`json:"carrier"`
 	Quantity int	`json:"quantity"`
 }
 
+// ID identifies an assignment by the (order, item, dc, carrier) combination
+// it represents. The Quantity is a decision made by the solver, not part of
+// the identity of the combination, so it is deliberately excluded here.
 func (i assignment) ID() string{
-	return i.Item.ItemID + "-" + i.DC.DCId + "-" + i.Carrier + "-" + fmt.Sprint(i.Quantity)
+	return i.Order + "-" + i.Item.ItemID + "-" + i.DC.DCId + "-" + i.Carrier
+}
+
+// orderDC pairs an order with a dc it might be (partly) fulfilled from. It
+// indexes the split-shipment indicator variables.
+type orderDC struct{
+	Order	string	`json:"order_id"`
+	DC		dc		`json:"dc"`
+}
+
+func (o orderDC) ID() string{
+	return o.Order + "-" + o.DC.DCId
 }
 
 // The Option for the solver.
@@ -96,6 +181,38 @@ type Option struct {
 	Limits struct {
 		Duration time.Duration `json:"duration" default:"10s"`
 	} `json:"limits"`
+	// Solver selects the underlying MIP solver provider, e.g. "highs",
+	// "cbc" or "gurobi", so cloud users can trade off runtime versus
+	// optimality without recompiling.
+	Solver string `json:"solver" default:"highs"`
+	// Threads caps the number of threads the solver may use. 0 leaves it up
+	// to the solver's own default.
+	Threads int `json:"threads,omitempty"`
+	// MIPGap is the relative optimality gap at which the solver may stop.
+	// highs' own default is 5%; we tighten that to 0% unless overridden.
+	MIPGap float64 `json:"mip_gap" default:"0"`
+	// Verbosity controls how much solver progress is logged: "off", "low",
+	// "medium" or "high".
+	Verbosity string `json:"verbosity" default:"off"`
+	// MaxReassignments bounds, when an InitialSolution is supplied, how many
+	// (order, item, dc, carrier) quantities may differ from that previous
+	// plan. 0 leaves it unconstrained.
+	MaxReassignments int `json:"max_reassignments,omitempty"`
+}
+
+// verbosity maps the Option.Verbosity string to the solver's verbosity
+// level, defaulting to off for unrecognized values.
+func verbosity(level string) mip.Verbosity {
+	switch level {
+	case "low":
+		return mip.Low
+	case "medium":
+		return mip.Medium
+	case "high":
+		return mip.High
+	default:
+		return mip.Off
+	}
 }
 
 // Output is the output of the solver.
@@ -105,24 +222,37 @@ type Output struct {
 	Items   []item  `json:"items,omitempty"`
 	Value   float64 `json:"value,omitempty"`
 	Assignments []assignment `json:"assignments"`
-	Cartons map[string]float64 `json:"cartons"`
+	Cartons map[string]int `json:"cartons"`
+	Orders []orderSummary `json:"orders,omitempty"`
+	SplitOrders int `json:"split_orders,omitempty"`
+}
+
+// orderSummary reports, for a single order, which dcs it was fulfilled from
+// and whether that counts as a split shipment.
+type orderSummary struct{
+	OrderID	string		`json:"order_id"`
+	DCs		[]string	`json:"dcs,omitempty"`
+	Split	bool		`json:"split"`
 }
 
 func solver(input input, opts Option) ([]Output, error) {
 	// We start by creating a MIP model.
 	m := mip.NewModel()
 
-	// create assignments (item, dc, carrier combinations)
+	// create assignments (order, item, dc, carrier combinations). There is
+	// exactly one assignment per combination; how many units of the item it
+	// carries is a decision made by the solver, not something we enumerate
+	// up front.
 	assignments := []assignment{}
-	for _, it := range input.Items{
-		for _, dc := range input.DCs{
-			for c := range input.CarrierCapacities[dc.DCId]{
-				for q := 0; q < int(it.Quantity); q++{
+	for _, o := range input.Orders{
+		for _, it := range o.Items{
+			for _, dc := range input.DCs{
+				for c := range input.CarrierCapacities[dc.DCId]{
 					newAssignment := assignment{
+						Order: o.OrderID,
 						Item: it,
 						DC: dc,
 						Carrier: c,
-						Quantity: q+1,
 					}
 					assignments = append(assignments, newAssignment)
 				}
@@ -142,54 +272,92 @@ func solver(input input, opts Option) ([]Output, error) {
 		}
 	}
 
-	itemToAssignments := make(map[string][]assignment, len(input.Items))
+	orderDCCombinations := []orderDC{}
+	for _, o := range input.Orders{
+		for _, dc := range input.DCs{
+			orderDCCombinations = append(orderDCCombinations, orderDC{Order: o.OrderID, DC: dc})
+		}
+	}
+
+	dcByID := make(map[string]dc, len(input.DCs))
+	for _, dc := range input.DCs{
+		dcByID[dc.DCId] = dc
+	}
+
+	// orderItemToAssignments groups assignments per (order, item), for the
+	// per-order fulfilment constraint.
+	orderItemToAssignments := make(map[string]map[string][]assignment, len(input.Orders))
+	// itemToDCAssignments groups assignments per (item, dc) across all
+	// orders, since inventory is a pool shared by every order.
+	itemToDCAssignments := make(map[string]map[string][]assignment)
+	// dcToCarrierToAssignments groups assignments per (dc, carrier) across
+	// all orders and items, since carrier capacity is likewise shared.
 	dcToCarrierToAssignments := make(map[string]map[string][]assignment, len(input.DCs))
+	// orderToDCAssignments groups assignments per (order, dc), to link the
+	// split-shipment indicator for that order-dc pair.
+	orderToDCAssignments := make(map[string]map[string][]assignment, len(input.Orders))
 	for _, as := range assignments{
 		itemId := as.Item.ItemID
-		_, ok := itemToAssignments[itemId]
+
+		_, ok := orderItemToAssignments[as.Order]
+		if !ok{
+			orderItemToAssignments[as.Order] = make(map[string][]assignment)
+		}
+		orderItemToAssignments[as.Order][itemId] = append(orderItemToAssignments[as.Order][itemId], as)
+
+		_, ok = itemToDCAssignments[itemId]
 		if !ok{
-			itemToAssignments[itemId] = []assignment{}
+			itemToDCAssignments[itemId] = make(map[string][]assignment)
 		}
-		itemToAssignments[itemId] = append(itemToAssignments[itemId], as)
+		itemToDCAssignments[itemId][as.DC.DCId] = append(itemToDCAssignments[itemId][as.DC.DCId], as)
+
 		_, ok = dcToCarrierToAssignments[as.DC.DCId]
 		if !ok{
 			dcToCarrierToAssignments[as.DC.DCId] = make(map[string][]assignment)
 		}
-		_, ok = dcToCarrierToAssignments[as.DC.DCId][as.Carrier]
+		dcToCarrierToAssignments[as.DC.DCId][as.Carrier] = append(dcToCarrierToAssignments[as.DC.DCId][as.Carrier], as)
+
+		_, ok = orderToDCAssignments[as.Order]
 		if !ok{
-			dcToCarrierToAssignments[as.DC.DCId][as.Carrier] = []assignment{}
+			orderToDCAssignments[as.Order] = make(map[string][]assignment)
 		}
-		dcToCarrierToAssignments[as.DC.DCId][as.Carrier] = append(dcToCarrierToAssignments[as.DC.DCId][as.Carrier], as)
+		orderToDCAssignments[as.Order][as.DC.DCId] = append(orderToDCAssignments[as.Order][as.DC.DCId], as)
 	}
-	
-	// x is a multimap representing a set of variables. It is initialized with a
-	// create function and, in this case one set of elements. The elements can
-	// be used as an index to the multimap. To retrieve a variable, call
-	// x.Get(element) where element is an element from the index set.
+
+	// x is a multimap representing a set of integer variables: one per
+	// (order, item, dc, carrier) combination, bounded by the quantity of the item
+	// being ordered. This replaces one boolean per unit with a single
+	// integer quantity variable, which keeps the model's size proportional
+	// to the number of combinations instead of the number of units.
 	x := model.NewMultiMap(
-		func(...assignment) mip.Bool{
-			return m.NewBool()
+		func(a ...assignment) mip.Int{
+			return m.NewInt(0, int64(a[0].Item.Quantity))
 		}, assignments)
 
 	// create another multimap which will hold the info about the number of
-	// cartons at each distribution center
-
+	// cartons at each distribution center. Cartons are integer: dispatchers
+	// are charged per whole carton, not per fraction of one.
 	cartons := model.NewMultiMap(
-		func(...carrier) mip.Float{
-			return m.NewFloat(0.0, 1000.0)
+		func(c ...carrier) mip.Int{
+			maxCartons := maxCartonsFor(input, c[0].DC.DCId, c[0].Carrier)
+			return m.NewInt(0, int64(maxCartons))
 		}, dcCarrierCombinations)
 
 	// We want to maximize the value of the knapsack.
 	m.Objective().SetMinimize()
 
-	/* Fulfilment constraint -> ensure all items are assigned */
-	for _, i := range input.Items{
-		fulfilment := m.NewConstraint(
-			mip.Equal,
-			i.Quantity,
-		)
-		for _, a := range itemToAssignments[i.ItemID]{
-			fulfilment.NewTerm(float64(a.Quantity), x.Get(a))
+	/* Fulfilment constraint -> ensure all items of every order are assigned.
+	The sum of the quantities assigned across all dc/carrier combinations for
+	an (order, item) must equal the quantity ordered. */
+	for _, o := range input.Orders{
+		for _, i := range o.Items{
+			fulfilment := m.NewConstraint(
+				mip.Equal,
+				i.Quantity,
+			)
+			for _, a := range orderItemToAssignments[o.OrderID][i.ItemID]{
+				fulfilment.NewTerm(1, x.Get(a))
+			}
 		}
 	}
 
@@ -202,55 +370,200 @@ func solver(input input, opts Option) ([]Output, error) {
 				input.CarrierCapacities[dcId][cId],
 			)
 			for _, as := range list{
-				carrier.NewTerm(as.Item.Volume * as.Item.Quantity, x.Get(as))
+				carrier.NewTerm(as.Item.Volume, x.Get(as))
 			}
 		}
 	}
 
 	/* Inventory constraint -> Consider the inventory of each item at the
-	distribution centers */
-	for _, i := range input.Items{
-		for _, dc := range input.DCs{
+	distribution centers. The quantity of an item assigned across all
+	orders and carriers at a dc cannot exceed that dc's inventory for the
+	item; inventory is a pool shared by every order. */
+	for itemId, dcAssignments := range itemToDCAssignments{
+		for dcId, list := range dcAssignments{
 			inventory := m.NewConstraint(
 				mip.LessThanOrEqual,
-				float64(dc.Inventory[i.ItemID]),
+				float64(dcByID[dcId].Inventory[itemId]),
 			)
-			for _, a := range itemToAssignments[i.ItemID]{
-				if a.DC.DCId == dc.DCId{
-					inventory.NewTerm(float64(a.Quantity), x.Get(a))
-				}
+			for _, a := range list{
+				inventory.NewTerm(1, x.Get(a))
 			}
 		}
 	}
 
+	/* split-shipment indicators -> y[order,dc] is forced to 1 if any item of
+	the order is assigned to that dc, via a big-M constraint. The order's
+	own total quantity is a safe value for M since no single dc can be
+	assigned more than that. */
+	orderQuantity := make(map[string]float64, len(input.Orders))
+	for _, o := range input.Orders{
+		total := 0.0
+		for _, i := range o.Items{
+			total += i.Quantity
+		}
+		orderQuantity[o.OrderID] = total
+	}
+
+	y := model.NewMultiMap(
+		func(...orderDC) mip.Bool{
+			return m.NewBool()
+		}, orderDCCombinations)
+
+	for _, od := range orderDCCombinations{
+		link := m.NewConstraint(
+			mip.LessThanOrEqual,
+			0.0,
+		)
+		link.NewTerm(-orderQuantity[od.Order], y.Get(od))
+		for _, a := range orderToDCAssignments[od.Order][od.DC.DCId]{
+			link.NewTerm(1, x.Get(a))
+		}
+	}
+
+	/* split-shipment cap -> optionally bound how many distinct dcs a single
+	order may be fulfilled from. Orders without an entry in MaxSplits are
+	left unconstrained. */
+	for _, o := range input.Orders{
+		maxSplits, ok := input.MaxSplits[o.OrderID]
+		if !ok{
+			continue
+		}
+		splitCap := m.NewConstraint(
+			mip.LessThanOrEqual,
+			float64(maxSplits),
+		)
+		for _, dc := range input.DCs{
+			splitCap.NewTerm(1, y.Get(orderDC{Order: o.OrderID, DC: dc}))
+		}
+	}
+
 	/* carton computation -> look at every distribution center and accumulate
-	the volume of all the assigned items, use the carton volume from the input to
-	compute the number of cartons that are necessary */
+	the volume of all the assigned items, use the carton volume from the input
+	to compute the number of cartons that are necessary. This is a
+	greater-than-or-equal constraint rather than an equality: it forces
+	cartons to be at least ceil(volume/CartonVolume), and the objective
+	(which charges per carton) pushes the solver to not pick more than it
+	needs. */
 	for _, dc := range dcCarrierCombinations{
 		cartonConstr := m.NewConstraint(
-			mip.Equal,
+			mip.GreaterThanOrEqual,
+			0.0,
+		)
+		cartonConstr.NewTerm(input.CartonVolume, cartons.Get(dc))
+		for _, a := range assignments{
+			if a.DC.DCId == dc.DC.DCId && a.Carrier == dc.Carrier{
+				cartonConstr.NewTerm(-a.Item.Volume, x.Get(a))
+			}
+		}
+	}
+
+	/* carton weight constraint -> the total weight assigned to a dc-carrier
+	combination cannot exceed its per-carton weight limit times the number of
+	cartons it uses. Combinations without a configured limit are left
+	unconstrained. */
+	for _, dc := range dcCarrierCombinations{
+		limit, ok := input.CartonWeightLimits[dc.DC.DCId][dc.Carrier]
+		if !ok || limit <= 0{
+			continue
+		}
+		weightConstr := m.NewConstraint(
+			mip.LessThanOrEqual,
 			0.0,
 		)
-		cartonConstr.NewTerm(-1, cartons.Get(dc))
+		weightConstr.NewTerm(-limit, cartons.Get(dc))
 		for _, a := range assignments{
 			if a.DC.DCId == dc.DC.DCId && a.Carrier == dc.Carrier{
-				cartonConstr.NewTerm(a.Item.Volume * float64(a.Quantity) * 1/input.CartonVolume, x.Get(a))
+				weightConstr.NewTerm(a.Item.Weight, x.Get(a))
 			}
 		}
 	}
 
+	/* piecewise-linear delivery cost -> for every dc-carrier combination,
+	model the cost of its carton count against the breakpoints of its
+	delivery cost curve. This is the classic "multiple choice" piecewise
+	linear formulation: one binary per segment selects which segment is
+	active, and a pair of weights interpolates between that segment's two
+	breakpoints. */
+	deliveryCost := make(map[string]mip.Float, len(dcCarrierCombinations))
+	for _, dc := range dcCarrierCombinations{
+		breakpoints := input.DeliveryCosts[dc.DC.DCId][dc.Carrier]
+		sort.Slice(breakpoints, func(a, b int) bool{
+			return breakpoints[a].Cartons < breakpoints[b].Cartons
+		})
+
+		cost := m.NewFloat(0.0, 1e12)
+		deliveryCost[dc.ID()] = cost
+
+		if len(breakpoints) >= 2{
+			maxCartons := maxCartonsFor(input, dc.DC.DCId, dc.Carrier)
+			if breakpoints[0].Cartons != 0 || breakpoints[len(breakpoints)-1].Cartons < maxCartons{
+				return nil, fmt.Errorf(
+					"delivery cost curve for dc %q carrier %q must span "+
+						"from 0 cartons to at least its carton cap %d, got "+
+						"%d..%d",
+					dc.DC.DCId, dc.Carrier, maxCartons,
+					breakpoints[0].Cartons, breakpoints[len(breakpoints)-1].Cartons,
+				)
+			}
+		}
+
+		if len(breakpoints) < 2{
+			// No curve was supplied; fall back to a flat per-carton rate,
+			// same as before the piecewise cost curve was introduced.
+			rate := 0.0
+			if len(breakpoints) == 1{
+				rate = breakpoints[0].Cost
+			}
+			flat := m.NewConstraint(mip.Equal, 0.0)
+			flat.NewTerm(1, cost)
+			flat.NewTerm(-rate, cartons.Get(dc))
+			continue
+		}
+
+		segments := len(breakpoints) - 1
+		segmentSelected := m.NewConstraint(mip.Equal, 1.0)
+		cartonsLink := m.NewConstraint(mip.Equal, 0.0)
+		cartonsLink.NewTerm(-1, cartons.Get(dc))
+		costLink := m.NewConstraint(mip.Equal, 0.0)
+		costLink.NewTerm(-1, cost)
+
+		for s := 0; s < segments; s++{
+			z := m.NewBool()
+			lambdaLow := m.NewFloat(0.0, 1.0)
+			lambdaHigh := m.NewFloat(0.0, 1.0)
+
+			segmentSelected.NewTerm(1, z)
+
+			// lambdaLow and lambdaHigh can only be non-zero while their
+			// segment is the active one (z == 1), and must then sum to 1.
+			active := m.NewConstraint(mip.Equal, 0.0)
+			active.NewTerm(1, lambdaLow)
+			active.NewTerm(1, lambdaHigh)
+			active.NewTerm(-1, z)
+
+			low, high := breakpoints[s], breakpoints[s+1]
+			cartonsLink.NewTerm(float64(low.Cartons), lambdaLow)
+			cartonsLink.NewTerm(float64(high.Cartons), lambdaHigh)
+			costLink.NewTerm(low.Cost, lambdaLow)
+			costLink.NewTerm(high.Cost, lambdaHigh)
+		}
+	}
+
 	/* objective function = handling costs + delivery costs */
 	/* handling costs: cost is based on number of cartons that need to be
 	handled at a distribution center */
-	/* delivery costs: cost is based on number of cartons that need to be
-	transported */
+	/* delivery costs: cost is read off each dc-carrier's delivery cost curve */
 	for _, dc := range dcCarrierCombinations {
-		m.Objective().NewTerm(input.DeliveryCosts[dc.DC.DCId][dc.Carrier], cartons.Get(dc))		// delivery costs
+		m.Objective().NewTerm(1, deliveryCost[dc.ID()])		// delivery costs
 		m.Objective().NewTerm(dc.DC.HandlingCost, cartons.Get(dc))	// handling costs
 	}
+	/* split-shipment penalty: orders prefer to consolidate at a single dc */
+	for _, od := range orderDCCombinations{
+		m.Objective().NewTerm(input.SplitPenalty, y.Get(od))
+	}
 
-	// We create a solver using the 'highs' provider
-	solver, err := mip.NewSolver("highs", m)
+	// We create a solver using the provider selected via Option.Solver
+	solver, err := mip.NewSolver(opts.Solver, m)
 	if err != nil {
 		return nil, err
 	}
@@ -263,13 +576,64 @@ func solver(input input, opts Option) ([]Output, error) {
 		return nil, err
 	}
 
-	// Set the relative gap to 0% (highs' default is 5%)
-	if err = solveOptions.SetMIPGapRelative(0); err != nil {
+	// Set the relative gap (highs' default is 5%, ours defaults to 0%)
+	if err = solveOptions.SetMIPGapRelative(opts.MIPGap); err != nil {
 		return nil, err
 	}
 
-	// Set verbose level to see a more detailed output
-	solveOptions.SetVerbosity(mip.Off)
+	// Cap the number of threads the solver may use, if requested
+	if opts.Threads > 0{
+		if err = solveOptions.SetMaximumThreads(opts.Threads); err != nil {
+			return nil, err
+		}
+	}
+
+	// Set verbosity level to see a more detailed output
+	solveOptions.SetVerbosity(verbosity(opts.Verbosity))
+
+	// Feed a previous plan (or heuristic) to the solver as a MIP start, and
+	// optionally bound how far the new plan may drift from it.
+	if len(input.InitialSolution) > 0{
+		initial := m.NewSolution()
+		for _, a := range input.InitialSolution{
+			initial.SetValue(x.Get(a), float64(a.Quantity))
+		}
+		if err = solveOptions.SetInitialSolution(initial); err != nil {
+			return nil, err
+		}
+
+		if opts.MaxReassignments > 0{
+			priorQuantity := make(map[string]int, len(input.InitialSolution))
+			for _, a := range input.InitialSolution{
+				priorQuantity[a.ID()] = a.Quantity
+			}
+
+			/* max reassignments constraint -> for every (order, item, dc,
+			carrier) combination, a binary "changed" indicator is forced to 1
+			whenever its quantity deviates from the previous plan, and the
+			total number of changed combinations is capped. */
+			reassignmentCap := m.NewConstraint(mip.LessThanOrEqual, float64(opts.MaxReassignments))
+			for _, a := range assignments{
+				prior := float64(priorQuantity[a.ID()])
+				bound := float64(a.Item.Quantity)
+
+				devPos := m.NewFloat(0.0, bound)
+				devNeg := m.NewFloat(0.0, bound)
+				deviation := m.NewConstraint(mip.Equal, prior)
+				deviation.NewTerm(1, x.Get(a))
+				deviation.NewTerm(-1, devPos)
+				deviation.NewTerm(1, devNeg)
+
+				changed := m.NewBool()
+				devLink := m.NewConstraint(mip.LessThanOrEqual, 0.0)
+				devLink.NewTerm(1, devPos)
+				devLink.NewTerm(1, devNeg)
+				devLink.NewTerm(-bound, changed)
+
+				reassignmentCap.NewTerm(1, changed)
+			}
+		}
+	}
 
 	solution, err := solver.Solve(solveOptions)
 	if err != nil {
@@ -287,10 +651,10 @@ func solver(input input, opts Option) ([]Output, error) {
 func format(
 	solution mip.Solution,
 	input input,
-	x model.MultiMap[mip.Bool, assignment],
+	x model.MultiMap[mip.Int, assignment],
 	assignments []assignment,
 	dcs []carrier,
-	cartons model.MultiMap[mip.Float, carrier],
+	cartons model.MultiMap[mip.Int, carrier],
 ) (output Output, err error) {
 	output.Status = "infeasible"
 	output.Runtime = solution.RunTime().String()
@@ -305,17 +669,46 @@ func format(
 		output.Value = solution.ObjectiveValue()
 
 		assignmentList := make([]assignment,0)
-		for _, assignment := range assignments {
-			if solution.Value(x.Get(assignment)) > 0.5{
-				assignmentList = append(assignmentList, assignment)
+		for _, as := range assignments {
+			quantity := int(solution.Value(x.Get(as)) + 0.5)
+			if quantity > 0{
+				as.Quantity = quantity
+				assignmentList = append(assignmentList, as)
 			}
 		}
 
 		output.Assignments = assignmentList
 
-		output.Cartons = make(map[string]float64)
+		output.Cartons = make(map[string]int)
 		for _, dc := range dcs{
-			output.Cartons[dc.DC.DCId+"-"+dc.Carrier] = solution.Value(cartons.Get(dc))
+			output.Cartons[dc.DC.DCId+"-"+dc.Carrier] = int(solution.Value(cartons.Get(dc)) + 0.5)
+		}
+
+		orderDCsUsed := make(map[string]map[string]bool, len(input.Orders))
+		for _, as := range assignmentList{
+			if orderDCsUsed[as.Order] == nil{
+				orderDCsUsed[as.Order] = make(map[string]bool)
+			}
+			orderDCsUsed[as.Order][as.DC.DCId] = true
+		}
+
+		output.Orders = make([]orderSummary, 0, len(input.Orders))
+		for _, o := range input.Orders{
+			dcIds := make([]string, 0, len(orderDCsUsed[o.OrderID]))
+			for dcId := range orderDCsUsed[o.OrderID]{
+				dcIds = append(dcIds, dcId)
+			}
+			sort.Strings(dcIds)
+
+			split := len(dcIds) > 1
+			if split{
+				output.SplitOrders++
+			}
+			output.Orders = append(output.Orders, orderSummary{
+				OrderID: o.OrderID,
+				DCs: dcIds,
+				Split: split,
+			})
 		}
 	} else {
 		return output, errors.New("no solution found")